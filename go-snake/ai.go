@@ -0,0 +1,230 @@
+package main
+
+// Agent decides the next direction for a player to move in, given the
+// current board. Wiring an Agent into gameLoop behind --ai turns the
+// local frontend into a spectator view of the snake playing itself.
+type Agent interface {
+	NextDirection(state *GameState, player *Player) Position
+}
+
+func directionTo(from, to Position) Position {
+	return Position{to.x - from.x, to.y - from.y}
+}
+
+var neighborDeltas = []Position{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+
+// fallbackDirection picks any direction that doesn't immediately kill the
+// snake, preferring to keep going straight. Used when no path to a pellet
+// exists.
+func fallbackDirection(state *GameState, player *Player) Position {
+	candidates := append([]Position{player.direction}, neighborDeltas...)
+	for _, delta := range candidates {
+		if delta == (Position{}) || isOpposite(delta, player.direction) {
+			continue
+		}
+		next := player.body[0].Add(delta)
+		if state.wallMode == WallSolid && !state.withinBounds(next) {
+			continue
+		}
+		if state.wallMode != WallSolid {
+			next = state.wrapPosition(next)
+		}
+		if insideAny(state.obstacles, next) || occupiesAny(state.players, next) {
+			continue
+		}
+		return delta
+	}
+	return player.direction
+}
+
+// BFSAgent shortest-paths the head to the nearest pellet on a grid that
+// treats every snake's body and the level's obstacles as walls.
+type BFSAgent struct{}
+
+func (BFSAgent) NextDirection(state *GameState, player *Player) Position {
+	if len(state.pellets) == 0 {
+		return fallbackDirection(state, player)
+	}
+
+	blocked := make(map[Position]bool)
+	for _, obstacle := range state.obstacles {
+		for x := obstacle.start.x; x <= obstacle.end.x; x++ {
+			for y := obstacle.start.y; y <= obstacle.end.y; y++ {
+				blocked[Position{x, y}] = true
+			}
+		}
+	}
+	for _, other := range state.players {
+		for _, part := range other.body {
+			blocked[part] = true
+		}
+	}
+
+	var path []Position
+	bestLen := -1
+	for _, pellet := range state.pellets {
+		if candidate := bfsPath(state.board, blocked, player.body[0], pellet.position); candidate != nil {
+			if bestLen == -1 || len(candidate) < bestLen {
+				path = candidate
+				bestLen = len(candidate)
+			}
+		}
+	}
+
+	if len(path) < 2 {
+		return fallbackDirection(state, player)
+	}
+	return directionTo(path[0], path[1])
+}
+
+// bfsPath finds the shortest path from start to goal on board, avoiding
+// every cell in blocked, and returns it as a list of positions including
+// both endpoints (or nil if goal is unreachable).
+func bfsPath(board Rectangle, blocked map[Position]bool, start, goal Position) []Position {
+	type node struct {
+		pos  Position
+		prev *node
+	}
+
+	visited := map[Position]bool{start: true}
+	queue := []*node{{pos: start}}
+	var goalNode *node
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur.pos.Equal(goal) {
+			goalNode = cur
+			break
+		}
+		for _, delta := range neighborDeltas {
+			next := cur.pos.Add(delta)
+			if !board.Contains(next) || visited[next] || blocked[next] {
+				continue
+			}
+			visited[next] = true
+			queue = append(queue, &node{pos: next, prev: cur})
+		}
+	}
+
+	if goalNode == nil {
+		return nil
+	}
+	var path []Position
+	for n := goalNode; n != nil; n = n.prev {
+		path = append([]Position{n.pos}, path...)
+	}
+	return path
+}
+
+// HamiltonianAgent precomputes a cycle covering every cell of the board
+// and follows it, taking a shortcut toward the pellet only when doing so
+// still leaves its own tail between it and the rest of the cycle.
+type HamiltonianAgent struct {
+	cycle []Position
+	index map[Position]int
+}
+
+func NewHamiltonianAgent(board Rectangle) *HamiltonianAgent {
+	cycle := buildHamiltonianCycle(board)
+	index := make(map[Position]int, len(cycle))
+	for i, pos := range cycle {
+		index[pos] = i
+	}
+	return &HamiltonianAgent{cycle: cycle, index: index}
+}
+
+// buildHamiltonianCycle constructs a Hamiltonian cycle over board: column
+// 0 straight down, then columns 1..width-1 combed up/down skipping row 0,
+// and a final return lane along row 0 back to the start. This only closes
+// into a single cycle when the width it combs is even, so an odd
+// board.Width() has its last column left out of the cycle entirely: a
+// pellet landing there is just never detoured for, rather than producing
+// a cycle with a non-adjacent jump in it.
+func buildHamiltonianCycle(board Rectangle) []Position {
+	width := board.Width()
+	if width%2 != 0 {
+		width--
+	}
+	height := board.Height()
+	ox, oy := board.start.x, board.start.y
+
+	cycle := make([]Position, 0, width*height)
+	for y := 0; y < height; y++ {
+		cycle = append(cycle, Position{ox, oy + y})
+	}
+	for x := 1; x < width; x++ {
+		if x%2 == 1 {
+			for y := height - 1; y >= 1; y-- {
+				cycle = append(cycle, Position{ox + x, oy + y})
+			}
+		} else {
+			for y := 1; y < height; y++ {
+				cycle = append(cycle, Position{ox + x, oy + y})
+			}
+		}
+	}
+	for x := width - 1; x >= 1; x-- {
+		cycle = append(cycle, Position{ox + x, oy})
+	}
+	return cycle
+}
+
+// distOnCycle returns how many forward steps along the cycle it takes to
+// get from a to b.
+func (h *HamiltonianAgent) distOnCycle(a, b Position) int {
+	n := len(h.cycle)
+	return ((h.index[b]-h.index[a])%n + n) % n
+}
+
+func (h *HamiltonianAgent) NextDirection(state *GameState, player *Player) Position {
+	head := player.body[0]
+	next := h.cycle[(h.index[head]+1)%len(h.cycle)]
+
+	if len(state.pellets) > 0 {
+		pellet := state.pellets[0].position
+		if _, onCycle := h.index[pellet]; onCycle {
+			tail := player.body[len(player.body)-1]
+			if h.distOnCycle(head, pellet) < h.distOnCycle(head, tail) {
+				if shortcut, ok := h.shortcutTowards(player, pellet); ok {
+					next = shortcut
+				}
+			}
+		}
+	}
+	return directionTo(head, next)
+}
+
+// shortcutTowards looks for a neighbor of the head that cuts across the
+// cycle toward pellet. A shortcut is only taken when its exit point is
+// still strictly ahead of the tail on the cycle, so the snake never
+// crosses its own tail.
+func (h *HamiltonianAgent) shortcutTowards(player *Player, pellet Position) (Position, bool) {
+	head := player.body[0]
+	tail := player.body[len(player.body)-1]
+	distHeadTail := h.distOnCycle(head, tail)
+	bestDist := h.distOnCycle(head, pellet)
+
+	var best Position
+	found := false
+	for _, delta := range neighborDeltas {
+		next := head.Add(delta)
+		if _, onCycle := h.index[next]; !onCycle {
+			continue
+		}
+		if containsPosition(player.body, next) {
+			continue
+		}
+		distHeadNext := h.distOnCycle(head, next)
+		distNextTail := h.distOnCycle(next, tail)
+		if distHeadNext == 0 || distHeadNext >= distHeadTail || distNextTail == 0 {
+			continue
+		}
+		if distNextPellet := h.distOnCycle(next, pellet); distNextPellet < bestDist {
+			best = next
+			bestDist = distNextPellet
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// maxBenchmarkTicks bounds a single run so a perfect Hamiltonian loop (or
+// a bugged agent) can't make the benchmark hang forever.
+const maxBenchmarkTicks = 100000
+
+type BenchmarkResult struct {
+	Runs         int
+	AverageScore float64
+	AverageTicks float64
+}
+
+func (r BenchmarkResult) String() string {
+	return fmt.Sprintf("%d runs: avg score %.2f, avg ticks-to-death %.2f", r.Runs, r.AverageScore, r.AverageTicks)
+}
+
+// RunBenchmark plays runs seeded, headless games with an agent built by
+// newAgent and reports its average final score and average ticks
+// survived.
+func RunBenchmark(newAgent func(board Rectangle) Agent, settings GameSettings, runs int, seedBase int64) BenchmarkResult {
+	board := Rectangle{
+		start: Position{x: 0, y: 0},
+		end:   Position{x: settings.boardWidth - 1, y: settings.boardHeight - 1},
+	}
+	spawn := Position{x: board.start.x + settings.boardWidth/2, y: board.start.y + settings.boardHeight/2}
+
+	var totalScore, totalTicks int
+	for i := 0; i < runs; i++ {
+		state := newGameState(board, settings.wallMode, nil, settings.pelletCount, seedBase+int64(i))
+		player := state.AddPlayer("bot", spawn, 0)
+		state.RefillPellets()
+
+		agent := newAgent(board)
+		ticks := 0
+		for !state.isGameOver() && ticks < maxBenchmarkTicks {
+			player.direction = agent.NextDirection(state, player)
+			state.Tick()
+			ticks++
+		}
+
+		totalScore += player.score
+		totalTicks += ticks
+	}
+
+	return BenchmarkResult{
+		Runs:         runs,
+		AverageScore: float64(totalScore) / float64(runs),
+		AverageTicks: float64(totalTicks) / float64(runs),
+	}
+}
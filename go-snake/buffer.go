@@ -0,0 +1,81 @@
+package main
+
+import "github.com/gdamore/tcell/v2"
+
+// Tile is a single cell in a Buffer: the rune to display and its style.
+type Tile struct {
+	Rune  rune
+	Style tcell.Style
+}
+
+// Buffer is an off-screen grid that game and frontend code draws into.
+// Nothing outside of a Renderer talks to a terminal directly, so the same
+// drawing code works for a live tcell.Screen, a test snapshot, or any
+// future frontend.
+type Buffer struct {
+	width  int
+	height int
+	tiles  []Tile
+}
+
+func newBuffer(width, height int) *Buffer {
+	return &Buffer{
+		width:  width,
+		height: height,
+		tiles:  make([]Tile, width*height),
+	}
+}
+
+func (b *Buffer) inBounds(x, y int) bool {
+	return x >= 0 && x < b.width && y >= 0 && y < b.height
+}
+
+func (b *Buffer) set(x, y int, r rune, style tcell.Style) {
+	if !b.inBounds(x, y) {
+		return
+	}
+	b.tiles[y*b.width+x] = Tile{Rune: r, Style: style}
+}
+
+func (b *Buffer) get(x, y int) Tile {
+	if !b.inBounds(x, y) {
+		return Tile{Rune: ' '}
+	}
+	return b.tiles[y*b.width+x]
+}
+
+func (b *Buffer) writeString(x, y int, s string, style tcell.Style) {
+	for i, r := range s {
+		b.set(x+i, y, r, style)
+	}
+}
+
+func (b *Buffer) clear() {
+	for i := range b.tiles {
+		b.tiles[i] = Tile{Rune: ' '}
+	}
+}
+
+// blit copies every tile onto screen, offsetting by offset.
+func (b *Buffer) blit(screen tcell.Screen, offset Position) {
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			tile := b.get(x, y)
+			screen.SetContent(offset.x+x, offset.y+y, tile.Rune, nil, tile.Style)
+		}
+	}
+}
+
+// Snapshot returns the buffer's runes as a row-major [][]rune, for tests to
+// assert against without a terminal.
+func (b *Buffer) Snapshot() [][]rune {
+	rows := make([][]rune, b.height)
+	for y := 0; y < b.height; y++ {
+		row := make([]rune, b.width)
+		for x := 0; x < b.width; x++ {
+			row[x] = b.get(x, y).Rune
+		}
+		rows[y] = row
+	}
+	return rows
+}
@@ -0,0 +1,377 @@
+package main
+
+import "math/rand"
+
+// Position is a single cell coordinate on the board.
+type Position struct {
+	x int
+	y int
+}
+
+func (p Position) Add(other Position) Position {
+	return Position{p.x + other.x, p.y + other.y}
+}
+
+func (p Position) Equal(other Position) bool {
+	return p.x == other.x && p.y == other.y
+}
+
+type Rectangle struct {
+	start Position
+	end   Position
+}
+
+func (r Rectangle) Width() int {
+	return r.end.x - r.start.x + 1
+}
+
+func (r Rectangle) Height() int {
+	return r.end.y - r.start.y + 1
+}
+
+func (r Rectangle) Contains(pos Position) bool {
+	return pos.x >= r.start.x && pos.x <= r.end.x && pos.y >= r.start.y && pos.y <= r.end.y
+}
+
+// WallMode controls what happens when a snake's head reaches the edge of
+// the board.
+type WallMode int
+
+const (
+	WallSolid  WallMode = iota // hitting the edge is fatal
+	WallWrap                   // the head wraps to the opposite edge
+	WallPortal                 // like WallWrap; reserved for paired portal tiles once levels define them
+)
+
+// Player is a single snake tracked by the engine. It carries no rendering
+// state so the same struct can be driven by the local tcell frontend or by
+// a remote SSH session.
+type Player struct {
+	id        string
+	body      []Position
+	direction Position
+	color     int
+	score     int
+	alive     bool
+}
+
+func (p *Player) Length() int {
+	return len(p.body)
+}
+
+// Move advances the snake by one cell in its current direction. Growth and
+// shrinking from pellets is resolved by GameState.Tick once the new head's
+// position is known to be safe.
+func (p *Player) Move() {
+	head := p.body[0].Add(p.direction)
+	p.body = append([]Position{head}, p.body...)
+}
+
+// PelletKind determines the effect eating a pellet has on a snake.
+type PelletKind int
+
+const (
+	PelletNormal PelletKind = iota // +1 score, grows by one cell
+	PelletGolden                   // +3 score, shrinks the tail
+	PelletPoison                   // game over for whoever eats it
+)
+
+type Pellet struct {
+	position Position
+	kind     PelletKind
+}
+
+// pelletWeights controls how often each kind is picked by Generate.
+var pelletWeights = []struct {
+	kind   PelletKind
+	weight int
+}{
+	{PelletNormal, 70},
+	{PelletGolden, 15},
+	{PelletPoison, 15},
+}
+
+func randomPelletKind(rng *rand.Rand) PelletKind {
+	total := 0
+	for _, w := range pelletWeights {
+		total += w.weight
+	}
+	roll := rng.Intn(total)
+	for _, w := range pelletWeights {
+		if roll < w.weight {
+			return w.kind
+		}
+		roll -= w.weight
+	}
+	return PelletNormal
+}
+
+// Generate picks a new pellet position inside board that doesn't overlap
+// any obstacle, player body, or existing pellet, and assigns it a weighted
+// random kind. rng is the GameState's own seeded source, so a run is
+// reproducible end to end given the same seed and inputs.
+func (p *Pellet) Generate(rng *rand.Rand, board Rectangle, obstacles []Rectangle, players map[string]*Player, pellets []Pellet) {
+	pos := randomPosition(rng, board)
+	for insideAny(obstacles, pos) || occupiesAny(players, pos) || pelletsContain(pellets, pos) {
+		pos = randomPosition(rng, board)
+	}
+	p.position = pos
+	p.kind = randomPelletKind(rng)
+}
+
+func randomPosition(rng *rand.Rand, board Rectangle) Position {
+	return Position{
+		x: rng.Intn(board.Width()) + board.start.x,
+		y: rng.Intn(board.Height()) + board.start.y,
+	}
+}
+
+func insideAny(rects []Rectangle, pos Position) bool {
+	for _, r := range rects {
+		if r.Contains(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+func occupiesAny(players map[string]*Player, pos Position) bool {
+	for _, player := range players {
+		if containsPosition(player.body, pos) {
+			return true
+		}
+	}
+	return false
+}
+
+func pelletsContain(pellets []Pellet, pos Position) bool {
+	for _, pellet := range pellets {
+		if pellet.position.Equal(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPosition(body []Position, pos Position) bool {
+	for _, part := range body {
+		if part.Equal(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// occupiedBody returns the cells of body that will still be occupied once
+// this tick's move resolves. A snake that isn't eating a pellet this tick
+// trims its tail after moving, so that cell is vacated and moving into it
+// is not a collision; a snake that is eating keeps its tail in place.
+func occupiedBody(body []Position, grows bool) []Position {
+	if grows || len(body) == 0 {
+		return body
+	}
+	return body[:len(body)-1]
+}
+
+// GameState is the headless engine shared by every frontend (local tcell,
+// SSH multiplayer, ...). It owns no rendering or input handling.
+type GameState struct {
+	board       Rectangle
+	wallMode    WallMode
+	obstacles   []Rectangle
+	players     map[string]*Player
+	pellets     []Pellet
+	pelletCount int
+	rng         *rand.Rand
+	gameOver    bool
+}
+
+// newGameState builds the headless engine. seed drives every random
+// decision the engine makes (pellet placement and kind), so two
+// GameStates built with the same seed and fed the same player inputs
+// produce an identical run.
+func newGameState(board Rectangle, wallMode WallMode, obstacles []Rectangle, pelletCount int, seed int64) *GameState {
+	if pelletCount < 1 {
+		pelletCount = 1
+	}
+	return &GameState{
+		board:       board,
+		wallMode:    wallMode,
+		obstacles:   obstacles,
+		players:     make(map[string]*Player),
+		pelletCount: pelletCount,
+		rng:         rand.New(rand.NewSource(seed)),
+	}
+}
+
+// AddPlayer spawns a new single-cell snake for id at spawn and returns it.
+// spawn is used as given; it is not checked against other snakes, pellets
+// or obstacles.
+func (g *GameState) AddPlayer(id string, spawn Position, color int) *Player {
+	player := &Player{
+		id:        id,
+		body:      []Position{spawn},
+		direction: Position{0, 0},
+		color:     color,
+		alive:     true,
+	}
+	g.players[id] = player
+	return player
+}
+
+func (g *GameState) RemovePlayer(id string) {
+	delete(g.players, id)
+}
+
+// RefillPellets tops g.pellets up to pelletCount, generating new ones as
+// needed. Frontends call it once after setup and Tick calls it whenever a
+// pellet is eaten.
+func (g *GameState) RefillPellets() {
+	for len(g.pellets) < g.pelletCount {
+		var pellet Pellet
+		pellet.Generate(g.rng, g.board, g.obstacles, g.players, g.pellets)
+		g.pellets = append(g.pellets, pellet)
+	}
+}
+
+// Tick advances every living player by one step and resolves collisions.
+// It returns the ids of players killed this tick.
+func (g *GameState) Tick() []string {
+	for _, player := range g.players {
+		if player.alive {
+			player.Move()
+			if g.wallMode != WallSolid {
+				player.body[0] = g.wrapPosition(player.body[0])
+			}
+		}
+	}
+
+	var killed []string
+	heads := make(map[string]Position)
+	grows := make(map[string]bool)
+	for id, player := range g.players {
+		if player.alive {
+			heads[id] = player.body[0]
+			grows[id] = g.pelletIndexAt(player.body[0]) >= 0
+		}
+	}
+
+	// heads only holds players that were alive before this pass started, so
+	// it also doubles as that start-of-pass alive snapshot: checking against
+	// it instead of other.alive keeps a same-tick double kill independent of
+	// the order g.players happens to be iterated in (map order is
+	// randomized by Go), since a player killed earlier in this same pass
+	// (e.g. by a wall) still collides as their old body for everyone else.
+	for id, head := range heads {
+		player := g.players[id]
+		if g.wallMode == WallSolid && !g.withinBounds(head) {
+			player.alive = false
+			killed = append(killed, id)
+			continue
+		}
+		if insideAny(g.obstacles, head) {
+			player.alive = false
+			killed = append(killed, id)
+			continue
+		}
+		for otherID, other := range g.players {
+			if otherID == id {
+				if containsPosition(occupiedBody(player.body[1:], grows[id]), head) {
+					player.alive = false
+					killed = append(killed, id)
+				}
+				continue
+			}
+			if _, aliveAtStart := heads[otherID]; !aliveAtStart {
+				continue
+			}
+			if containsPosition(occupiedBody(other.body, grows[otherID]), head) {
+				player.alive = false
+				killed = append(killed, id)
+			}
+		}
+	}
+
+	for id, head := range heads {
+		player := g.players[id]
+		if !player.alive {
+			continue
+		}
+		if idx := g.pelletIndexAt(head); idx >= 0 {
+			g.eatPellet(id, idx, &killed)
+		} else {
+			player.body = player.body[:len(player.body)-1]
+		}
+	}
+
+	if single := g.soloPlayer(); single != nil {
+		g.gameOver = !single.alive
+	}
+
+	return killed
+}
+
+func (g *GameState) pelletIndexAt(pos Position) int {
+	for i, pellet := range g.pellets {
+		if pellet.position.Equal(pos) {
+			return i
+		}
+	}
+	return -1
+}
+
+// eatPellet applies the effect of the pellet at index idx to the player
+// whose head just reached it, then removes the pellet and tops the
+// pellets back up.
+func (g *GameState) eatPellet(id string, idx int, killed *[]string) {
+	player := g.players[id]
+	pellet := g.pellets[idx]
+	g.pellets = append(g.pellets[:idx], g.pellets[idx+1:]...)
+
+	switch pellet.kind {
+	case PelletPoison:
+		player.alive = false
+		*killed = append(*killed, id)
+	case PelletGolden:
+		player.score += 3
+		for i := 0; i < 3 && len(player.body) > 1; i++ {
+			player.body = player.body[:len(player.body)-1]
+		}
+	default:
+		player.score++
+	}
+
+	g.RefillPellets()
+}
+
+// soloPlayer returns the lone player when the engine is driving a
+// single-player frontend, or nil when it is hosting multiple snakes.
+func (g *GameState) soloPlayer() *Player {
+	if len(g.players) != 1 {
+		return nil
+	}
+	for _, player := range g.players {
+		return player
+	}
+	return nil
+}
+
+func (g *GameState) withinBounds(pos Position) bool {
+	return pos.x >= g.board.start.x && pos.x <= g.board.end.x &&
+		pos.y >= g.board.start.y && pos.y <= g.board.end.y
+}
+
+// wrapPosition brings pos back inside the board by wrapping it modulo the
+// board's dimensions, for WallWrap and WallPortal.
+func (g *GameState) wrapPosition(pos Position) Position {
+	width := g.board.Width()
+	height := g.board.Height()
+	x := ((pos.x-g.board.start.x)%width+width)%width + g.board.start.x
+	y := ((pos.y-g.board.start.y)%height+height)%height + g.board.start.y
+	return Position{x: x, y: y}
+}
+
+// isGameOver reports whether the single-player frontend's snake has died.
+func (g *GameState) isGameOver() bool {
+	return g.gameOver
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	gossh "github.com/gliderlabs/ssh"
+)
+
+// idleTimeout disconnects a session that hasn't sent a keypress in this
+// long, so an abandoned connection doesn't sit on the board forever.
+const idleTimeout = 2 * time.Minute
+
+var playerColors = []tcell.Color{
+	tcell.ColorGreen,
+	tcell.ColorBlue,
+	tcell.ColorYellow,
+	tcell.ColorPurple,
+	tcell.ColorAqua,
+	tcell.ColorOrange,
+}
+
+// Session is one connected SSH client playing on the shared board. input
+// holds at most one requested direction change, queued by handleSession on
+// keypress and resolved against the player's current direction once per
+// tick by Hub.run, the same pending-direction pattern main.go's gameLoop
+// uses for the local frontend so a burst of keypresses within one tick
+// can't chain into a same-tick reversal.
+type Session struct {
+	id       string
+	sess     gossh.Session
+	screen   tcell.Screen
+	buf      *Buffer
+	renderer Renderer
+	player   *Player
+	lastSeen time.Time
+	input    chan Position
+}
+
+// queueDirection replaces any previously queued, not-yet-applied direction
+// request with dir, so only the latest keypress before the next tick is
+// kept.
+func (s *Session) queueDirection(dir Position) {
+	select {
+	case <-s.input:
+	default:
+	}
+	s.input <- dir
+}
+
+// Hub owns the shared GameState and every connected Session. Register and
+// Unregister are the only ways callers touch hub state, so all mutation
+// happens on the single goroutine run by Hub.run; Redraw only signals that
+// a frame has been broadcast.
+type Hub struct {
+	settings   GameSettings
+	state      *GameState
+	sessions   map[*Session]*Player
+	Register   chan *Session
+	Unregister chan *Session
+	Redraw     chan struct{}
+}
+
+func NewHub(settings GameSettings) *Hub {
+	board := Rectangle{
+		start: Position{x: 1, y: 1},
+		end:   Position{x: settings.boardWidth, y: settings.boardHeight},
+	}
+	return &Hub{
+		settings:   settings,
+		state:      newGameState(board, settings.wallMode, nil, settings.pelletCount, settings.seed),
+		sessions:   make(map[*Session]*Player),
+		Register:   make(chan *Session),
+		Unregister: make(chan *Session),
+		Redraw:     make(chan struct{}, 1),
+	}
+}
+
+// run is the hub's single event loop: it serializes registration, removal
+// and tick/broadcast so the shared GameState is never touched concurrently.
+func (h *Hub) run() {
+	ticker := time.NewTicker(time.Duration(h.settings.gameSpeed) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case sess := <-h.Register:
+			h.addSession(sess)
+			h.broadcast()
+
+		case sess := <-h.Unregister:
+			h.removeSession(sess)
+			h.broadcast()
+
+		case <-ticker.C:
+			h.evictIdle()
+			h.resolvePending()
+			killed := h.state.Tick()
+			h.removeDead(killed)
+			h.broadcast()
+		}
+	}
+}
+
+func (h *Hub) addSession(sess *Session) {
+	color := len(h.sessions) % len(playerColors)
+	spawn := randomPosition(h.state.rng, h.state.board)
+	sess.player = h.state.AddPlayer(sess.id, spawn, color)
+	h.sessions[sess] = sess.player
+	h.state.RefillPellets()
+}
+
+// removeSession drops sess from hub-side bookkeeping only. sess.input is
+// closed by handleSession itself once its event loop exits, since only the
+// sender of a channel should close it; closing it here could race a
+// still-running handleSession trying to queue a keypress on it.
+func (h *Hub) removeSession(sess *Session) {
+	h.state.RemovePlayer(sess.id)
+	delete(h.sessions, sess)
+}
+
+// removeDead drops each killed player from the shared engine so a dead
+// snake doesn't linger in the scoreboard forever. Their Session stays
+// connected and registered: broadcast keeps drawing a death notice into
+// their Buffer (via sess.player, which is still valid) until they quit.
+func (h *Hub) removeDead(killed []string) {
+	for _, id := range killed {
+		h.state.RemovePlayer(id)
+	}
+}
+
+// evictIdle disconnects sessions that haven't sent a keypress in
+// idleTimeout by closing the underlying SSH session, which cancels its
+// context and lets handleSession's own exit path unregister it — rather
+// than deleting hub-side bookkeeping while handleSession keeps running.
+func (h *Hub) evictIdle() {
+	for sess := range h.sessions {
+		if time.Since(sess.lastSeen) > idleTimeout {
+			sess.sess.Close()
+		}
+	}
+}
+
+// resolvePending applies each session's queued direction request, if any,
+// validated against that player's current direction so a same-tick
+// reversal can't chain into suicide the way a naive per-keypress
+// assignment would.
+func (h *Hub) resolvePending() {
+	for sess, player := range h.sessions {
+		select {
+		case dir := <-sess.input:
+			if !isOpposite(dir, player.direction) {
+				player.direction = dir
+			}
+		default:
+		}
+	}
+}
+
+// broadcast draws the current board into every connected session's own
+// Buffer and renders it through that session's Renderer.
+func (h *Hub) broadcast() {
+	for sess := range h.sessions {
+		sess.buf.clear()
+		h.draw(sess.buf)
+		if sess.player != nil && !sess.player.alive {
+			h.drawDeathNotice(sess.buf, sess.player)
+		}
+		sess.renderer.Render(sess.buf)
+	}
+	select {
+	case h.Redraw <- struct{}{}:
+	default:
+	}
+}
+
+// drawDeathNotice writes a dead player's own status line into buf instead
+// of writing around the Renderer, so it goes out in the same blit as the
+// rest of the frame and keeps showing every frame until the session quits.
+// That blit (Buffer.blit) copies every tile each frame; it does not skip
+// tiles that are unchanged since the previous frame.
+func (h *Hub) drawDeathNotice(buf *Buffer, player *Player) {
+	style := tcell.StyleDefault.Foreground(tcell.ColorRed)
+	msg := fmt.Sprintf("you died! final length: %d (press q to quit)", player.Length())
+	buf.writeString(h.state.board.start.x, h.state.board.start.y-1, msg, style)
+}
+
+func (h *Hub) draw(buf *Buffer) {
+	drawBox(buf, h.state.board)
+	drawObstacles(buf, h.state.obstacles)
+	for _, pellet := range h.state.pellets {
+		drawPellet(buf, pellet)
+	}
+	for _, player := range h.state.players {
+		if player.alive {
+			drawPlayer(buf, player)
+		}
+	}
+	h.drawScoreboard(buf)
+}
+
+func (h *Hub) drawScoreboard(buf *Buffer) {
+	players := make([]*Player, 0, len(h.state.players))
+	for _, p := range h.state.players {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].Length() > players[j].Length()
+	})
+
+	x := h.state.board.end.x + 3
+	for i, p := range players {
+		style := tcell.StyleDefault.Foreground(playerColors[p.color])
+		line := fmt.Sprintf("%d. %s (%d)", i+1, p.id, p.Length())
+		buf.writeString(x, h.state.board.start.y+i, line, style)
+	}
+}
+
+// ListenAndServe starts the SSH multiplayer server: each incoming
+// connection becomes a Session registered with a shared Hub, all drawing
+// to a board maintained by the same headless engine the local frontend
+// in main.go uses.
+func ListenAndServe(addr string, settings GameSettings) error {
+	hub := NewHub(settings)
+	go hub.run()
+
+	server := &gossh.Server{
+		Addr: addr,
+		Handler: func(sess gossh.Session) {
+			handleSession(hub, sess)
+		},
+	}
+	return server.ListenAndServe()
+}
+
+func handleSession(hub *Hub, sess gossh.Session) {
+	pty, winCh, isPty := sess.Pty()
+	if !isPty {
+		io.WriteString(sess, "snake-tui requires a PTY\n")
+		sess.Exit(1)
+		return
+	}
+
+	screen, err := tcell.NewTerminfoScreenFromTty(sessionTTY{sess: sess, pty: pty, winCh: winCh})
+	if err != nil {
+		io.WriteString(sess, fmt.Sprintf("failed to start screen: %v\n", err))
+		sess.Exit(1)
+		return
+	}
+	if err := screen.Init(); err != nil {
+		sess.Exit(1)
+		return
+	}
+	defer screen.Fini()
+
+	width, height := screen.Size()
+	session := &Session{
+		id:       sess.User(),
+		sess:     sess,
+		screen:   screen,
+		buf:      newBuffer(width, height),
+		renderer: newTcellRenderer(screen, Position{0, 0}),
+		lastSeen: time.Now(),
+		input:    make(chan Position, 1),
+	}
+
+	hub.Register <- session
+	defer func() {
+		hub.Unregister <- session
+		close(session.input)
+	}()
+
+	events := make(chan tcell.Event, 8)
+	go screen.ChannelEvents(events, sess.Context().Done())
+
+	for {
+		select {
+		case <-sess.Context().Done():
+			return
+		case ev := <-events:
+			switch ev := ev.(type) {
+			case *tcell.EventResize:
+				width, height := ev.Size()
+				session.buf = newBuffer(width, height)
+			case *tcell.EventKey:
+				session.lastSeen = time.Now()
+				if dir, ok := requestedDirection(ev); ok {
+					session.queueDirection(dir)
+				}
+				if ev.Key() == tcell.KeyEscape || ev.Rune() == 'q' {
+					return
+				}
+			}
+		}
+	}
+}
+
+// sessionTTY adapts a gliderlabs/ssh session + pty into the tcell.Tty
+// interface so tcell can drive a terminal over the SSH connection instead
+// of a local pty.
+type sessionTTY struct {
+	sess  gossh.Session
+	pty   gossh.Pty
+	winCh <-chan gossh.Window
+}
+
+func (t sessionTTY) Read(p []byte) (int, error)  { return t.sess.Read(p) }
+func (t sessionTTY) Write(p []byte) (int, error) { return t.sess.Write(p) }
+func (t sessionTTY) Close() error                { return nil }
+func (t sessionTTY) Start() error                { return nil }
+func (t sessionTTY) Stop() error                 { return nil }
+func (t sessionTTY) Drain() error                { return nil }
+func (t sessionTTY) WindowSize() (tcell.WindowSize, error) {
+	return tcell.WindowSize{Width: t.pty.Window.Width, Height: t.pty.Window.Height}, nil
+}
+
+// NotifyResize satisfies tcell.Tty: it runs cb every time the SSH client
+// reports a new terminal size, so tcell learns of the resize (and queues
+// its own *tcell.EventResize) the same way it would off a SIGWINCH on a
+// local pty, instead of only session.buf being rebuilt.
+func (t sessionTTY) NotifyResize(cb func()) {
+	go func() {
+		for range t.winCh {
+			cb()
+		}
+	}()
+}
+
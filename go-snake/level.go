@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// Level is a loaded ASCII board layout: one line per row, `#` walls,
+// `.` floor, `S` the player's spawn point. Modeled after the tile-based
+// level files used by the termloop examples.
+type Level struct {
+	Width     int
+	Height    int
+	Obstacles []Rectangle
+	Spawn     Position
+}
+
+// LoadLevel reads an ASCII level file from path.
+func LoadLevel(path string) (*Level, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	level := &Level{}
+	scanner := bufio.NewScanner(f)
+	y := 0
+	sawSpawn := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > level.Width {
+			level.Width = len(line)
+		}
+		for x, r := range line {
+			switch r {
+			case '#':
+				level.Obstacles = append(level.Obstacles, Rectangle{
+					start: Position{x: x, y: y},
+					end:   Position{x: x, y: y},
+				})
+			case 'S':
+				level.Spawn = Position{x: x, y: y}
+				sawSpawn = true
+			}
+		}
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	level.Height = y
+
+	if level.Width == 0 || level.Height == 0 {
+		return nil, fmt.Errorf("level %s: empty layout", path)
+	}
+	if !sawSpawn {
+		return nil, fmt.Errorf("level %s: missing S spawn marker", path)
+	}
+	return level, nil
+}
@@ -1,140 +1,150 @@
 package main
 
 import (
-	"math/rand"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 )
 
-type Position struct {
-	x int
-	y int
-}
-
-func (p Position) Add(other Position) Position {
-	return Position{p.x + other.x, p.y + other.y}
-}
-
-func (p Position) Equal(other Position) bool {
-	return p.x == other.x && p.y == other.y
-}
-
-type Rectangle struct {
-	start Position
-	end   Position
-}
-
-func (r Rectangle) Width() int {
-	return r.end.x - r.start.x + 1
+type GameSettings struct {
+	boardHeight int
+	boardWidth  int
+	gameSpeed   int
+	wallMode    WallMode
+	pelletCount int
+	levelPath   string
+	seed        int64
+	recordPath  string
+	ghostPath   string
+	aiMode      string
 }
 
-func (r Rectangle) Height() int {
-	return r.end.y - r.start.y + 1
+// newAgent builds the Agent named by mode ("bfs" or "hamiltonian"), or nil
+// for human control.
+func newAgent(mode string, board Rectangle) Agent {
+	switch mode {
+	case "bfs":
+		return BFSAgent{}
+	case "hamiltonian":
+		return NewHamiltonianAgent(board)
+	default:
+		return nil
+	}
 }
 
-type Player struct {
-	body      []Position
-	length    int
-	direction Position
-}
+const localPlayerID = "local"
 
-func (p *Player) Move(pelletPos Position) {
-	head := p.body[0].Add(p.direction)
-	p.body = append([]Position{head}, p.body...)
-	if !head.Equal(pelletPos) {
-		p.body = p.body[:len(p.body)-1]
+func parseWallMode(s string) WallMode {
+	switch s {
+	case "wrap":
+		return WallWrap
+	case "portal":
+		return WallPortal
+	default:
+		return WallSolid
 	}
 }
 
-func (p *Player) Draw(screen tcell.Screen) {
+func drawPlayer(buf *Buffer, player *Player) {
 	style := tcell.StyleDefault.Foreground(tcell.ColorGreen)
-	for _, part := range p.body {
-		screen.SetContent(part.x, part.y, '*', nil, style)
+	for _, part := range player.body {
+		buf.set(part.x, part.y, '*', style)
 	}
 
-	head := p.body[0]
+	head := player.body[0]
 	var char rune
 	switch {
-	case p.direction.Equal(Position{0, -1}):
+	case player.direction.Equal(Position{0, -1}):
 		char = '^'
-	case p.direction.Equal(Position{0, 1}):
+	case player.direction.Equal(Position{0, 1}):
 		char = 'v'
-	case p.direction.Equal(Position{-1, 0}):
+	case player.direction.Equal(Position{-1, 0}):
 		char = '<'
-	case p.direction.Equal(Position{1, 0}):
+	case player.direction.Equal(Position{1, 0}):
 		char = '>'
 	default:
 		char = 'X'
 	}
-	screen.SetContent(head.x, head.y, char, nil, style)
-}
-
-type Pellet struct {
-	position Position
+	buf.set(head.x, head.y, char, style)
 }
 
-func (p *Pellet) Draw(screen tcell.Screen) {
+func drawPellet(buf *Buffer, pellet Pellet) {
 	style := tcell.StyleDefault.Foreground(tcell.ColorRed)
-	screen.SetContent(p.position.x, p.position.y, 'O', nil, style)
-}
-
-func (p *Pellet) Generate(board Rectangle, player Player) {
-	rand.Seed(time.Now().UnixNano())
-	pos := Position{
-		x: rand.Intn(board.Width()) + board.start.x,
-		y: rand.Intn(board.Height()) + board.start.y,
-	}
-	for containsPosition(player.body, pos) {
-		pos = Position{
-			x: rand.Intn(board.Width()) + board.start.x,
-			y: rand.Intn(board.Height()) + board.start.y,
-		}
+	switch pellet.kind {
+	case PelletGolden:
+		style = tcell.StyleDefault.Foreground(tcell.ColorYellow)
+	case PelletPoison:
+		style = tcell.StyleDefault.Foreground(tcell.ColorPurple)
 	}
-	p.position = pos
+	buf.set(pellet.position.x, pellet.position.y, 'O', style)
 }
 
-type GameState struct {
-	board    Rectangle
-	player   Player
-	pellet   Pellet
-	score    int
-	gameOver bool
-}
-
-func (g *GameState) isGameOver() bool {
-	head := g.player.body[0]
-	isWithinBounds := head.x >= g.board.start.x && head.x <= g.board.end.x &&
-		head.y >= g.board.start.y && head.y <= g.board.end.y
-	isInsideSelf := containsPosition(g.player.body[1:], head)
-	return !isWithinBounds || isInsideSelf
+func drawGhost(buf *Buffer, player *Player) {
+	style := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	for _, part := range player.body {
+		buf.set(part.x, part.y, '*', style)
+	}
 }
 
-func containsPosition(body []Position, pos Position) bool {
-	for _, part := range body {
-		if part.Equal(pos) {
-			return true
+func drawObstacles(buf *Buffer, obstacles []Rectangle) {
+	style := tcell.StyleDefault.Foreground(tcell.ColorWhite)
+	for _, obstacle := range obstacles {
+		for x := obstacle.start.x; x <= obstacle.end.x; x++ {
+			for y := obstacle.start.y; y <= obstacle.end.y; y++ {
+				buf.set(x, y, '#', style)
+			}
 		}
 	}
-	return false
 }
 
-func drawBox(screen tcell.Screen, board Rectangle) {
+func drawBox(buf *Buffer, board Rectangle) {
 	horizontalStyle := tcell.StyleDefault.Foreground(tcell.ColorWhite)
 
 	// Draw top and bottom borders
 	for x := board.start.x; x <= board.end.x; x++ {
-		screen.SetContent(x, board.start.y-1, '-', nil, horizontalStyle)
-		screen.SetContent(x, board.end.y+1, '-', nil, horizontalStyle)
+		buf.set(x, board.start.y-1, '-', horizontalStyle)
+		buf.set(x, board.end.y+1, '-', horizontalStyle)
 	}
 
 	// Draw left and right borders
 	for y := board.start.y; y <= board.end.y; y++ {
-		screen.SetContent(board.start.x-1, y, '|', nil, horizontalStyle)
-		screen.SetContent(board.end.x+1, y, '|', nil, horizontalStyle)
+		buf.set(board.start.x-1, y, '|', horizontalStyle)
+		buf.set(board.end.x+1, y, '|', horizontalStyle)
 	}
 }
 
+// loadObstacles loads and offsets the obstacles and spawn point from
+// levelPath onto board, or returns no obstacles and the board's center
+// when levelPath is empty. Shared by gameLoop and playReplay so a replay
+// reconstructs the exact board a recorded run was played on.
+func loadObstacles(board Rectangle, boardWidth, boardHeight int, levelPath string) ([]Rectangle, Position) {
+	spawn := Position{x: board.start.x + boardWidth/2, y: board.start.y + boardHeight/2}
+	if levelPath == "" {
+		return nil, spawn
+	}
+
+	level, err := LoadLevel(levelPath)
+	if err != nil {
+		panic(err)
+	}
+	offset := board.start
+	var obstacles []Rectangle
+	for _, obstacle := range level.Obstacles {
+		obstacles = append(obstacles, Rectangle{
+			start: obstacle.start.Add(offset),
+			end:   obstacle.end.Add(offset),
+		})
+	}
+	return obstacles, level.Spawn.Add(offset)
+}
+
+// gameLoop is the local single-player frontend. It drives the shared
+// headless engine with tcell input/output, the same engine the SSH
+// multiplayer frontend in hub.go drives for remote sessions.
 func gameLoop(screen tcell.Screen, settings GameSettings) {
 	screen.Clear()
 
@@ -143,79 +153,184 @@ func gameLoop(screen tcell.Screen, settings GameSettings) {
 		start: Position{x: (width - settings.boardWidth) / 2, y: (height - settings.boardHeight) / 2},
 		end:   Position{x: (width + settings.boardWidth) / 2, y: (height + settings.boardHeight) / 2},
 	}
-	player := Player{
-		body:      []Position{{x: board.start.x + settings.boardWidth/2, y: board.start.y + settings.boardHeight/2}},
-		direction: Position{0, 0},
-	}
-	pellet := Pellet{}
-	pellet.Generate(board, player)
 
-	state := GameState{
-		board:  board,
-		player: player,
-		pellet: pellet,
-	}
+	obstacles, spawn := loadObstacles(board, settings.boardWidth, settings.boardHeight, settings.levelPath)
 
-	for !state.gameOver {
-		drawBox(screen, state.board)
-		state.player.Draw(screen)
-		state.pellet.Draw(screen)
+	state := newGameState(board, settings.wallMode, obstacles, settings.pelletCount, settings.seed)
+	player := state.AddPlayer(localPlayerID, spawn, 0)
+	state.RefillPellets()
 
-		screen.Show()
-		ev := screen.PollEvent()
-		switch ev := ev.(type) {
-		case *tcell.EventKey:
-			switch ev.Key() {
-			case tcell.KeyEscape:
+	replay := NewReplay(settings, localPlayerID)
+
+	var ghost *Ghost
+	if settings.ghostPath != "" {
+		if prior, err := LoadReplay(settings.ghostPath); err == nil {
+			ghost = NewGhost(prior, board, settings.wallMode, obstacles, settings.pelletCount, spawn)
+		}
+	}
+
+	// agent is non-nil in spectator mode: the tick handler drives the
+	// snake itself instead of reading pending keyboard input.
+	agent := newAgent(settings.aiMode, board)
+
+	events := make(chan tcell.Event, 8)
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		for {
+			ev := screen.PollEvent()
+			select {
+			case events <- ev:
+			case <-quit:
 				return
-			case tcell.KeyUp:
-				if state.player.direction != (Position{0, 1}) {
-					state.player.direction = Position{0, -1}
-				}
-			case tcell.KeyDown:
-				if state.player.direction != (Position{0, -1}) {
-					state.player.direction = Position{0, 1}
-				}
-			case tcell.KeyLeft:
-				if state.player.direction != (Position{1, 0}) {
-					state.player.direction = Position{-1, 0}
+			}
+		}
+	}()
+
+	// pending holds at most one requested direction change. It is applied
+	// against the player's *current* direction at the start of the next
+	// tick rather than at queue time, so a burst of keypresses within one
+	// tick (e.g. Up then Left before the first tick fires) can't chain
+	// into a reversal that kills the snake on its own neck.
+	var pending *Position
+
+	ticker := time.NewTicker(time.Duration(settings.gameSpeed) * time.Millisecond)
+	defer ticker.Stop()
+
+	buf := newBuffer(width, height)
+	renderer := newTcellRenderer(screen, Position{0, 0})
+
+	tick := 0
+	for !state.isGameOver() {
+		buf.clear()
+		drawBox(buf, state.board)
+		drawObstacles(buf, state.obstacles)
+		if ghost != nil {
+			drawGhost(buf, ghost.player)
+		}
+		drawPlayer(buf, player)
+		for _, pellet := range state.pellets {
+			drawPellet(buf, pellet)
+		}
+		renderer.Render(buf)
+
+		select {
+		case ev := <-events:
+			switch ev := ev.(type) {
+			case *tcell.EventKey:
+				if ev.Key() == tcell.KeyEscape || ev.Rune() == 'q' {
+					saveReplay(replay, settings.recordPath)
+					return
 				}
-			case tcell.KeyRight:
-				if state.player.direction != (Position{-1, 0}) {
-					state.player.direction = Position{1, 0}
+				if agent == nil {
+					if dir, ok := requestedDirection(ev); ok {
+						pending = &dir
+					}
 				}
-			case tcell.KeyRune:
-				if ev.Rune() == 'q' {
-					return
+			}
+		case <-ticker.C:
+			if agent != nil {
+				player.direction = agent.NextDirection(state, player)
+			} else if pending != nil {
+				if isOpposite(*pending, player.direction) {
+					pending = nil
+				} else {
+					player.direction = *pending
+					pending = nil
 				}
 			}
+			replay.Record(tick, player.direction)
+			state.Tick()
+			if ghost != nil {
+				ghost.Tick(tick)
+			}
+			tick++
 		}
+	}
+	saveReplay(replay, settings.recordPath)
+}
 
-		state.player.Move(state.pellet.position)
-		if state.player.body[0].Equal(state.pellet.position) {
-			state.score++
-			state.pellet.Generate(state.board, state.player)
-		}
-
-		if state.isGameOver() {
-			state.gameOver = true
-		}
+func saveReplay(replay *Replay, path string) {
+	if path == "" {
+		return
+	}
+	if err := replay.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save replay: %v\n", err)
+	}
+}
 
-		time.Sleep(time.Duration(settings.gameSpeed) * time.Millisecond)
+// requestedDirection maps a keypress to the direction it asks for, without
+// validating it against the snake's current direction yet.
+func requestedDirection(ev *tcell.EventKey) (Position, bool) {
+	switch ev.Key() {
+	case tcell.KeyUp:
+		return Position{0, -1}, true
+	case tcell.KeyDown:
+		return Position{0, 1}, true
+	case tcell.KeyLeft:
+		return Position{-1, 0}, true
+	case tcell.KeyRight:
+		return Position{1, 0}, true
 	}
+	return Position{}, false
 }
 
-type GameSettings struct {
-	boardHeight int
-	boardWidth  int
-	gameSpeed   int
+func isOpposite(a, b Position) bool {
+	return a.Add(b) == (Position{0, 0}) && a != (Position{0, 0})
+}
+
+// resolveSeed turns --seed into an RNG seed: empty means random, a string
+// that parses as a number is used as-is so a recorded numeric seed is
+// reproducible by typing it back in, and anything else is hashed so a
+// name can be used as a seed too.
+func resolveSeed(seedFlag string) int64 {
+	if seedFlag == "" {
+		return time.Now().UnixNano()
+	}
+	if n, err := strconv.ParseInt(seedFlag, 10, 64); err == nil {
+		return n
+	}
+	return seedFromString(seedFlag)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplayCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
+		runBenchmarkCommand(os.Args[2:])
+		return
+	}
+
+	sshAddr := flag.String("ssh", "", "listen address for the multiplayer SSH server, e.g. :2222 (if empty, runs locally instead)")
+	wall := flag.String("wall", "solid", "wall behavior: solid, wrap, or portal")
+	pellets := flag.Int("pellets", 1, "number of pellets on the board at once")
+	level := flag.String("level", "", "path to an ASCII level file (# walls, . floor, S spawn)")
+	seed := flag.String("seed", "", "seed for pellet generation (a number or a name to hash); random if empty")
+	record := flag.String("record", "", "path to save this run's replay to")
+	ghost := flag.String("ghost", "", "path to a prior replay to race as a ghost")
+	ai := flag.String("ai", "", "watch an autopilot play instead of controlling the snake: bfs or hamiltonian")
+	flag.Parse()
+
 	settings := GameSettings{
 		boardHeight: 17,
 		boardWidth:  71,
 		gameSpeed:   100,
+		wallMode:    parseWallMode(*wall),
+		pelletCount: *pellets,
+		levelPath:   *level,
+		seed:        resolveSeed(*seed),
+		recordPath:  *record,
+		ghostPath:   *ghost,
+		aiMode:      *ai,
+	}
+
+	if *sshAddr != "" {
+		if err := ListenAndServe(*sshAddr, settings); err != nil {
+			panic(err)
+		}
+		return
 	}
 
 	screen, err := tcell.NewScreen()
@@ -234,3 +349,107 @@ func main() {
 
 	gameLoop(screen, settings)
 }
+
+// runBenchmarkCommand implements `snake-tui benchmark`: it plays an agent
+// headlessly over N seeded runs and reports average score and
+// ticks-to-death.
+func runBenchmarkCommand(args []string) {
+	fs := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	ai := fs.String("ai", "bfs", "agent to benchmark: bfs or hamiltonian")
+	runs := fs.Int("runs", 100, "number of seeded runs to play")
+	seed := fs.String("seed", "benchmark", "base seed (a number or a name to hash)")
+	pellets := fs.Int("pellets", 1, "number of pellets on the board at once")
+	fs.Parse(args)
+
+	settings := GameSettings{
+		boardHeight: 17,
+		boardWidth:  71,
+		pelletCount: *pellets,
+	}
+	result := RunBenchmark(func(board Rectangle) Agent {
+		return newAgent(*ai, board)
+	}, settings, *runs, resolveSeed(*seed))
+
+	fmt.Println(result)
+}
+
+// runReplayCommand implements `snake-tui replay <file>`: it re-runs the
+// engine deterministically from a saved Replay so a run can be verified
+// or watched back.
+func runReplayCommand(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: snake-tui replay <file>")
+		os.Exit(1)
+	}
+
+	replay, err := LoadReplay(fs.Arg(0))
+	if err != nil {
+		panic(err)
+	}
+
+	settings := GameSettings{
+		boardHeight: replay.BoardHeight,
+		boardWidth:  replay.BoardWidth,
+		gameSpeed:   100,
+		wallMode:    replay.WallMode,
+		pelletCount: replay.PelletCount,
+		levelPath:   replay.LevelPath,
+		seed:        replay.Seed,
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		panic(err)
+	}
+	defer screen.Fini()
+
+	if err := screen.Init(); err != nil {
+		panic(err)
+	}
+	screen.SetStyle(tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack))
+	screen.Clear()
+
+	playReplay(screen, settings, replay)
+}
+
+// playReplay drives the engine with a Replay's recorded directions instead
+// of live input, at the same tick rate the run was recorded at.
+func playReplay(screen tcell.Screen, settings GameSettings, replay *Replay) {
+	screen.Clear()
+
+	width, height := screen.Size()
+	board := Rectangle{
+		start: Position{x: (width - settings.boardWidth) / 2, y: (height - settings.boardHeight) / 2},
+		end:   Position{x: (width + settings.boardWidth) / 2, y: (height + settings.boardHeight) / 2},
+	}
+
+	obstacles, spawn := loadObstacles(board, settings.boardWidth, settings.boardHeight, settings.levelPath)
+	state := newGameState(board, settings.wallMode, obstacles, settings.pelletCount, settings.seed)
+	player := state.AddPlayer(replay.PlayerID, spawn, 0)
+	state.RefillPellets()
+
+	buf := newBuffer(width, height)
+	renderer := newTcellRenderer(screen, Position{0, 0})
+	ticker := time.NewTicker(time.Duration(settings.gameSpeed) * time.Millisecond)
+	defer ticker.Stop()
+
+	lastTick := replay.Entries[len(replay.Entries)-1].Tick
+	for tick := 0; !state.isGameOver() && tick <= lastTick; tick++ {
+		buf.clear()
+		drawBox(buf, state.board)
+		drawObstacles(buf, state.obstacles)
+		drawPlayer(buf, player)
+		for _, pellet := range state.pellets {
+			drawPellet(buf, pellet)
+		}
+		renderer.Render(buf)
+
+		<-ticker.C
+		if dir, ok := replay.DirectionAt(tick); ok {
+			player.direction = dir
+		}
+		state.Tick()
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Renderer gets a drawn Buffer in front of a player. Game logic and the
+// drawBox/drawPlayer/drawPellet helpers never reach for a Renderer
+// themselves; only frontend code (gameLoop, hub.go) does, once per frame
+// after filling a Buffer.
+type Renderer interface {
+	Render(buf *Buffer)
+}
+
+// tcellRenderer blits a Buffer onto a live tcell.Screen.
+type tcellRenderer struct {
+	screen tcell.Screen
+	offset Position
+}
+
+func newTcellRenderer(screen tcell.Screen, offset Position) *tcellRenderer {
+	return &tcellRenderer{screen: screen, offset: offset}
+}
+
+func (r *tcellRenderer) Render(buf *Buffer) {
+	buf.blit(r.screen, r.offset)
+	r.screen.Show()
+}
+
+// stringRenderer keeps the last rendered Buffer as plain text instead of
+// drawing to a terminal, so tests can drive the engine for N ticks and
+// assert on a snapshot.
+type stringRenderer struct {
+	last [][]rune
+}
+
+func (r *stringRenderer) Render(buf *Buffer) {
+	r.last = buf.Snapshot()
+}
+
+func (r *stringRenderer) String() string {
+	lines := make([]string, len(r.last))
+	for i, row := range r.last {
+		lines[i] = string(row)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestStringRendererSnapshotsEngineState drives the headless engine for a
+// few ticks through a stringRenderer and asserts the snapshot tracks the
+// head's actual position, so a broken Tick or Renderer wiring fails it.
+func TestStringRendererSnapshotsEngineState(t *testing.T) {
+	board := Rectangle{start: Position{x: 1, y: 1}, end: Position{x: 5, y: 3}}
+	state := newGameState(board, WallSolid, nil, 1, 42)
+	player := state.AddPlayer("p1", Position{x: 2, y: 2}, 0)
+	player.direction = Position{1, 0}
+
+	buf := newBuffer(board.end.x+2, board.end.y+2)
+	renderer := &stringRenderer{}
+
+	snapshot := func() string {
+		buf.clear()
+		drawBox(buf, state.board)
+		drawPlayer(buf, player)
+		renderer.Render(buf)
+		return renderer.String()
+	}
+	rowAt := func(s string, y int) string {
+		return strings.Split(s, "\n")[y]
+	}
+
+	first := snapshot()
+	if head := rowAt(first, 2)[2]; head != '>' {
+		t.Fatalf("expected the head glyph '>' at x=2 before any tick, got row %q", rowAt(first, 2))
+	}
+
+	for i := 0; i < 3; i++ {
+		state.Tick()
+	}
+	last := snapshot()
+
+	if first == last {
+		t.Fatalf("snapshot unchanged after 3 ticks; Tick or the Renderer wiring isn't advancing the board:\n%s", last)
+	}
+	if head := rowAt(last, 2)[5]; head != '>' {
+		t.Fatalf("expected the head glyph '>' at x=5 after 3 ticks moving right, got row %q", rowAt(last, 2))
+	}
+	if old := rowAt(last, 2)[2]; old == '>' {
+		t.Fatalf("head glyph still at its starting x=2 after 3 ticks, got row %q", rowAt(last, 2))
+	}
+}
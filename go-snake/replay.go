@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"os"
+)
+
+// ReplayEntry is one tick's recorded input.
+type ReplayEntry struct {
+	Tick      int
+	Direction Position
+}
+
+// Replay is a deterministic recording of one player's inputs against a
+// given seed and board setup. Because pellet generation is seeded from the
+// same value and draws the same number of times only when the board,
+// obstacles and pellet count match, replaying the recorded directions
+// reproduces an identical run only if the GameState is rebuilt from these
+// same fields rather than guessed at.
+type Replay struct {
+	Seed        int64
+	PlayerID    string
+	BoardWidth  int
+	BoardHeight int
+	WallMode    WallMode
+	PelletCount int
+	LevelPath   string
+	Entries     []ReplayEntry
+}
+
+func NewReplay(settings GameSettings, playerID string) *Replay {
+	return &Replay{
+		Seed:        settings.seed,
+		PlayerID:    playerID,
+		BoardWidth:  settings.boardWidth,
+		BoardHeight: settings.boardHeight,
+		WallMode:    settings.wallMode,
+		PelletCount: settings.pelletCount,
+		LevelPath:   settings.levelPath,
+	}
+}
+
+func (r *Replay) Record(tick int, dir Position) {
+	r.Entries = append(r.Entries, ReplayEntry{Tick: tick, Direction: dir})
+}
+
+// DirectionAt returns the direction recorded for tick, if any.
+func (r *Replay) DirectionAt(tick int) (Position, bool) {
+	for _, entry := range r.Entries {
+		if entry.Tick == tick {
+			return entry.Direction, true
+		}
+	}
+	return Position{}, false
+}
+
+func (r *Replay) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var replay Replay
+	if err := json.Unmarshal(data, &replay); err != nil {
+		return nil, err
+	}
+	return &replay, nil
+}
+
+// seedFromString hashes an arbitrary string into a seed, so --seed accepts
+// either a number or a player name.
+func seedFromString(s string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return int64(h.Sum64())
+}
+
+// Ghost replays a prior Replay against its own isolated GameState, so it
+// can be drawn as a second snake racing the live player on the same seed.
+type Ghost struct {
+	replay *Replay
+	state  *GameState
+	player *Player
+}
+
+func NewGhost(replay *Replay, board Rectangle, wallMode WallMode, obstacles []Rectangle, pelletCount int, spawn Position) *Ghost {
+	state := newGameState(board, wallMode, obstacles, pelletCount, replay.Seed)
+	player := state.AddPlayer("ghost", spawn, 1)
+	state.RefillPellets()
+	return &Ghost{replay: replay, state: state, player: player}
+}
+
+func (g *Ghost) Tick(tick int) {
+	if !g.player.alive {
+		return
+	}
+	if dir, ok := g.replay.DirectionAt(tick); ok {
+		g.player.direction = dir
+	}
+	g.state.Tick()
+}